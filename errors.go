@@ -0,0 +1,35 @@
+package recty
+
+import "fmt"
+
+// ShaderCompileError reports that one of the shaders passed to newProgram
+// failed to compile. Stage identifies which one, e.g. "vertex".
+type ShaderCompileError struct {
+    Stage string
+    Log   string
+}
+
+func (e *ShaderCompileError) Error() string {
+    return fmt.Sprintf("recty: %s shader failed to compile: %s", e.Stage, e.Log)
+}
+
+// ProgramLinkError reports that a program failed to link.
+type ProgramLinkError struct {
+    Log string
+}
+
+func (e *ProgramLinkError) Error() string {
+    return fmt.Sprintf("recty: program failed to link: %s", e.Log)
+}
+
+// LocationError reports that an attribute or uniform that Recty relies on
+// was not found in a linked program, which GetAttribLocation/
+// GetUniformLocation otherwise report only as a silent -1.
+type LocationError struct {
+    Kind string // "attribute" or "uniform"
+    Name string
+}
+
+func (e *LocationError) Error() string {
+    return fmt.Sprintf("recty: %s %q not found in program", e.Kind, e.Name)
+}
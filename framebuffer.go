@@ -0,0 +1,93 @@
+package recty
+
+import (
+    "fmt"
+    "image"
+
+    "github.com/go-gl/gl"
+    "github.com/snorredc/gome"
+)
+
+// Framebuffer is an offscreen render target: a GL FBO with a single colour
+// texture attachment. It lets a frame (or part of one) be rendered to a
+// texture instead of the screen, for post-processing chains, caching
+// expensive UI into a texture, or screenshotting.
+type Framebuffer struct {
+    fbo gl.Framebuffer
+    tex *Texture
+    w, h int
+
+    prevViewport [4]int32
+    prevFBO      int32
+}
+
+// NewFramebuffer allocates a w x h offscreen colour target.
+func NewFramebuffer(w, h int) (*Framebuffer, error) {
+    tex := NewTexture(image.NewRGBA(image.Rect(0, 0, w, h)))
+
+    fbo := gl.GenFramebuffer()
+    fbo.Bind()
+    fbo.Texture2D(gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, tex.id, 0)
+    if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+        fbo.Delete()
+        tex.Delete()
+        return nil, fmt.Errorf("recty: framebuffer incomplete (status 0x%x)", status)
+    }
+    gl.Framebuffer(0).Bind()
+
+    return &Framebuffer{fbo: fbo, tex: tex, w: w, h: h}, nil
+}
+
+// Bind makes fb the current render target and resizes the viewport to its
+// dimensions, saving the previously bound framebuffer and viewport for
+// Unbind. Framebuffers nest correctly: binding fb while another Framebuffer
+// is already bound restores that one, not the default framebuffer, on
+// Unbind.
+func (fb *Framebuffer) Bind() {
+    gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &fb.prevFBO)
+    gl.GetIntegerv(gl.VIEWPORT, &fb.prevViewport)
+    fb.fbo.Bind()
+    gl.Viewport(0, 0, fb.w, fb.h)
+}
+
+// Unbind restores whichever framebuffer and viewport were current before
+// the matching Bind.
+func (fb *Framebuffer) Unbind() {
+    gl.Framebuffer(fb.prevFBO).Bind()
+    gl.Viewport(int(fb.prevViewport[0]), int(fb.prevViewport[1]), int(fb.prevViewport[2]), int(fb.prevViewport[3]))
+}
+
+// Texture returns fb's colour attachment, usable as a texture source in a
+// subsequent Draw (Bind it, then draw rects with UVs over its [0,1] range).
+func (fb *Framebuffer) Texture() *Texture {
+    return fb.tex
+}
+
+// Pixels flushes recty's pending draws, then reads fb's colour attachment
+// back as packed RGBA8 pixels, row-major from the bottom-left.
+func (fb *Framebuffer) Pixels(recty *Recty) ([]uint8, error) {
+    recty.Flush()
+    recty.FlushLines()
+
+    // keep the state saved by a caller's own Bind, so its Unbind still
+    // restores what was current before it.
+    prevFBO, prevViewport := fb.prevFBO, fb.prevViewport
+    fb.Bind()
+    defer func() {
+        fb.Unbind()
+        fb.prevFBO, fb.prevViewport = prevFBO, prevViewport
+    }()
+
+    pixels := make([]uint8, fb.w*fb.h*4)
+    gl.ReadPixels(0, 0, fb.w, fb.h, gl.RGBA, gl.UNSIGNED_BYTE, pixels)
+    if err := gome.GetError(); err != nil {
+        return nil, err
+    }
+    return pixels, nil
+}
+
+// Delete deletes the framebuffer and its colour texture.
+func (fb *Framebuffer) Delete() {
+    fb.fbo.Delete()
+    fb.tex.Delete()
+}
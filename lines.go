@@ -0,0 +1,188 @@
+package recty
+
+import (
+    "github.com/go-gl/gl"
+    "github.com/snorredc/gome"
+)
+
+// lineVertSource is the source code for the line vertex shader. As with
+// vertSource, gl_Position is repurposed to carry the segment's endpoints
+// through to the geometry shader rather than an actual vertex position.
+const lineVertSource = `#version 150 core
+
+in vec4 endpoints;
+in vec4 color;
+
+out vec4 vColor;
+out vec4 vEndpoints;
+
+void main() {
+    gl_Position = endpoints;
+    vColor = color;
+    vEndpoints = endpoints;
+}
+`
+
+// lineGeomSource is the source code for the line geometry shader. It turns
+// each point (a line segment's two endpoints) into the oriented quad
+// between them, width units wide.
+const lineGeomSource = `#version 150 core
+
+layout(points) in;
+layout(triangle_strip, max_vertices = 4) out;
+
+in vec4 vColor[];
+in vec4 vEndpoints[];
+
+uniform mat3 transform;
+uniform float width;
+
+out vec4 fColor;
+
+void main() {
+    fColor = vColor[0];
+    vec2 p1 = vEndpoints[0].xy;
+    vec2 p2 = vEndpoints[0].zw;
+
+    vec2 dir = normalize(p2 - p1);
+    vec2 perp = vec2(-dir.y, dir.x) * (width * 0.5);
+
+    gl_Position = vec4(transform * vec3(p1 + perp, 1), 1.0);
+    EmitVertex();
+    gl_Position = vec4(transform * vec3(p1 - perp, 1), 1.0);
+    EmitVertex();
+    gl_Position = vec4(transform * vec3(p2 + perp, 1), 1.0);
+    EmitVertex();
+    gl_Position = vec4(transform * vec3(p2 - perp, 1), 1.0);
+    EmitVertex();
+}
+`
+
+// lineFragSource is the source code for the line fragment shader.
+const lineFragSource = `#version 150 core
+
+precision highp float;
+
+in vec4 fColor;
+
+out vec4 outColor;
+
+void main() {
+    outColor = fColor;
+}
+`
+
+// defaultLineWidth is used when Recty.LineWidth is left at zero.
+const defaultLineWidth = 0.01
+
+// initLines sets up the second geometry-shader program and its VAO/VBO used
+// to draw line segments, sharing the same overall setup as the rect
+// program built in Init.
+func (recty *Recty) initLines() error {
+    if recty.LineWidth == 0 {
+        recty.LineWidth = defaultLineWidth
+    }
+
+    recty.lineVao = gl.GenVertexArray()
+    recty.lineVao.Bind()
+
+    program, err := newProgram(lineVertSource, lineGeomSource, lineFragSource, nil)
+    if err != nil {
+        return err
+    }
+    recty.lineProgram = program
+    recty.lineProgram.Use()
+
+    recty.lineVbo = gl.GenBuffer()
+    recty.lineVbo.Bind(gl.ARRAY_BUFFER)
+    gl.BufferData(gl.ARRAY_BUFFER, 8*4*recty.Capacity, nil, gl.DYNAMIC_DRAW)
+
+    attrEndpoints, err := requireAttrib(recty.lineProgram, "endpoints")
+    if err != nil {
+        return err
+    }
+    attrEndpoints.AttribPointer(4, gl.FLOAT, false, 8*4, uintptr(0))
+    attrEndpoints.EnableArray()
+    attrColor, err := requireAttrib(recty.lineProgram, "color")
+    if err != nil {
+        return err
+    }
+    attrColor.AttribPointer(4, gl.FLOAT, false, 8*4, uintptr(4*4))
+    attrColor.EnableArray()
+
+    recty.lineTransform, err = requireUniform(recty.lineProgram, "transform")
+    if err != nil {
+        return err
+    }
+    recty.lineWidthLoc, err = requireUniform(recty.lineProgram, "width")
+    if err != nil {
+        return err
+    }
+    recty.lineWidthLoc.Uniform1f(recty.LineWidth)
+
+    if err := gome.GetError(); err != nil {
+        return err
+    }
+
+    recty.linesReady = true
+    recty.lineTransform.UniformMatrix3f(false, &[9]float32{1, 0, 0, 0, 1, 0, 0, 0, 1})
+
+    // switch back to the active material's program, which is the default
+    // for Draw.
+    recty.materials[recty.material].program.Use()
+    return gome.GetError()
+}
+
+// DrawLine draws a single coloured line segment from (x1, y1) to (x2, y2),
+// width units wide, using Recty.LineWidth.
+func (recty *Recty) DrawLine(x1, y1, x2, y2 float32, rgba [4]float32) {
+    recty.DrawLines([8]float32{x1, y1, x2, y2, rgba[0], rgba[1], rgba[2], rgba[3]})
+}
+
+// DrawLines draws coloured line segments directly to the screen. Each
+// segment is represented as
+//
+//     []float32{x1, y1, x2, y2, r, g, b, a}
+//
+// where (x1, y1) and (x2, y2) are the segment's endpoints and (r, g, b, a)
+// is the RGBA colour. All segments are drawn at the current Recty.LineWidth
+// and can be freely mixed with Draw/DrawTextured rects within a frame.
+func (recty *Recty) DrawLines(lines ...[8]float32) {
+    recty.QueueLines(lines...)
+    recty.FlushLines()
+}
+
+// QueueLines appends lines to the pending line batch without drawing them.
+// Call FlushLines to upload and draw everything queued so far.
+func (recty *Recty) QueueLines(lines ...[8]float32) {
+    recty.linesQueue = append(recty.linesQueue, lines...)
+}
+
+// FlushLines uploads the queued lines into the line VBO ring buffer and
+// draws them, then clears the queue. See Flush for the upload strategy.
+func (recty *Recty) FlushLines() {
+    if len(recty.linesQueue) == 0 {
+        return
+    }
+    recty.lineProgram.Use()
+    recty.lineWidthLoc.Uniform1f(recty.LineWidth)
+    recty.lineVao.Bind()
+    recty.lineVbo.Bind(gl.ARRAY_BUFFER)
+    for len(recty.linesQueue) > 0 {
+        chunk := recty.linesQueue
+        if len(chunk) > recty.Capacity {
+            chunk = chunk[:recty.Capacity]
+        }
+        offset, orphan, next := ringAdvance(recty.linesRing, len(chunk), recty.Capacity)
+        if orphan {
+            gl.BufferData(gl.ARRAY_BUFFER, 8*4*recty.Capacity, nil, gl.DYNAMIC_DRAW)
+        }
+        gl.BufferSubData(gl.ARRAY_BUFFER, 8*4*offset, chunk)
+        gl.DrawArrays(gl.POINTS, offset, len(chunk))
+        recty.linesRing = next
+        recty.linesQueue = recty.linesQueue[len(chunk):]
+    }
+    recty.linesQueue = recty.linesQueue[:0]
+    recty.vao.Bind()
+    recty.materials[recty.material].program.Use()
+}
@@ -0,0 +1,66 @@
+package recty
+
+import "github.com/go-gl/gl"
+
+// litFragSource is the fragment shader for LitMaterial. Since rects are
+// flat quads with no per-fragment surface normal, lighting is simplified to
+// a single directional term shared by the whole quad: the z component of
+// lightDir (how much the light faces the viewer) scales the diffuse
+// contribution, blended with ambient.
+const litFragSource = `#version 150 core
+
+precision highp float;
+
+in vec4 fColor;
+in vec2 fTexcoord;
+
+out vec4 outColor;
+
+uniform sampler2D tex;
+uniform vec3 lightDir;
+uniform vec3 lightColor;
+uniform float ambient;
+
+void main() {
+    vec4 texColor = texture(tex, fTexcoord);
+    vec4 base = vec4(fColor.rgb * fColor.a + texColor.rgb * (1.0 - fColor.a), fColor.a + texColor.a * (1.0 - fColor.a));
+
+    float diffuse = max(normalize(lightDir).z, 0.0);
+    float lit = ambient + (1.0 - ambient) * diffuse;
+    outColor = vec4(base.rgb * lit * lightColor, base.a);
+}
+`
+
+// LitMaterial is a reference Material demonstrating the extension point: a
+// directional light plus an ambient term modulate each rect's colour,
+// inspired by a simple Phong setup but without per-fragment normals.
+type LitMaterial struct {
+    // LightDir is the direction the light shines from; only the z component
+    // of its normalised direction (how much it faces the viewer) affects
+    // flat quads.
+    LightDir [3]float32
+    // LightColor tints the lit result.
+    LightColor [3]float32
+    // Ambient is the fraction of colour kept even with no direct light.
+    Ambient float32
+}
+
+// NewLitMaterial returns a LitMaterial with a light facing the viewer
+// head-on and a modest ambient term.
+func NewLitMaterial() *LitMaterial {
+    return &LitMaterial{
+        LightDir:   [3]float32{0, 0, 1},
+        LightColor: [3]float32{1, 1, 1},
+        Ambient:    0.2,
+    }
+}
+
+// FragmentSource implements Material.
+func (lit *LitMaterial) FragmentSource() string { return litFragSource }
+
+// SetUniforms implements Material.
+func (lit *LitMaterial) SetUniforms(program gl.Program) {
+    program.GetUniformLocation("lightDir").Uniform3f(lit.LightDir[0], lit.LightDir[1], lit.LightDir[2])
+    program.GetUniformLocation("lightColor").Uniform3f(lit.LightColor[0], lit.LightColor[1], lit.LightColor[2])
+    program.GetUniformLocation("ambient").Uniform1f(lit.Ambient)
+}
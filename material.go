@@ -0,0 +1,82 @@
+package recty
+
+import (
+    "errors"
+
+    "github.com/go-gl/gl"
+)
+
+// Material is a pluggable fragment stage for rects. Every material shares
+// Recty's point-to-quad geometry stage: the vertex and geometry shaders that
+// turn each [15]float32 rect into a transformed, optionally rotated and
+// UV-mapped quad. A material only supplies the fragment shader that decides
+// the final colour of each fragment, plus whatever uniforms it needs beyond
+// the shared transform.
+type Material interface {
+    // FragmentSource returns the material's fragment shader source. It must
+    // declare `in vec4 fColor;` and `in vec2 fTexcoord;` to match the
+    // shared geometry stage's outputs, and write to `out vec4 outColor;`.
+    FragmentSource() string
+
+    // SetUniforms is called with the material's own program current, after
+    // Recty has set the shared transform uniform, to set any uniforms the
+    // material declares beyond it. It runs on every Flush of the active
+    // material, not just when SetMaterial switches to it, so a material's
+    // exported fields (e.g. LitMaterial's LightDir) can be mutated
+    // frame-to-frame and take effect on the next Draw without forcing a
+    // program switch.
+    SetUniforms(program gl.Program)
+}
+
+// basicMaterial is recty's original material: fColor blended with a texture
+// sample, alpha-premultiplied. See fragSource.
+type basicMaterial struct{}
+
+func (basicMaterial) FragmentSource() string { return fragSource }
+func (basicMaterial) SetUniforms(gl.Program) {}
+
+// Basic is the default material, active until SetMaterial is called. It is
+// registered automatically by Init.
+var Basic Material = basicMaterial{}
+
+// materialProgram is a material's compiled program together with the
+// uniform location Recty needs to drive it every frame.
+type materialProgram struct {
+    program   gl.Program
+    transform gl.UniformLocation
+}
+
+// RegisterMaterial compiles mat against the shared geometry stage and
+// readies it for use with SetMaterial. Materials must be registered, once,
+// before being passed to SetMaterial; Init registers Basic automatically.
+func (recty *Recty) RegisterMaterial(mat Material) error {
+    program, err := newProgram(vertSource, geomSource, mat.FragmentSource(), bindRectAttribs)
+    if err != nil {
+        return err
+    }
+    transform, err := requireUniform(program, "transform")
+    if err != nil {
+        return err
+    }
+    if recty.materials == nil {
+        recty.materials = map[Material]*materialProgram{}
+    }
+    recty.materials[mat] = &materialProgram{program: program, transform: transform}
+    return nil
+}
+
+// SetMaterial flushes any rects queued under the current material, then
+// makes mat the active material for subsequent Draw/DrawTextured/Queue
+// calls. mat must already have been registered with RegisterMaterial.
+func (recty *Recty) SetMaterial(mat Material) error {
+    mp, ok := recty.materials[mat]
+    if !ok {
+        return errors.New("recty: material not registered, call RegisterMaterial first")
+    }
+    recty.Flush()
+    recty.material = mat
+    mp.program.Use()
+    mp.transform.UniformMatrix3f(false, &recty.transform)
+    mat.SetUniforms(mp.program)
+    return nil
+}
@@ -0,0 +1,79 @@
+package recty
+
+import (
+    "testing"
+
+    "github.com/go-gl/gl"
+)
+
+// spyMaterial is a Material whose fragment stage is Basic's, used only to
+// count how many times SetUniforms is invoked.
+type spyMaterial struct {
+    calls int
+}
+
+func (m *spyMaterial) FragmentSource() string { return fragSource }
+func (m *spyMaterial) SetUniforms(gl.Program) { m.calls++ }
+
+func TestSetMaterialRegistersAndActivates(t *testing.T) {
+    recty := &Recty{}
+    if err := recty.Init(); err != nil {
+        t.Fatalf("Init: %v", err)
+    }
+    defer recty.Delete()
+
+    spy := &spyMaterial{}
+    if err := recty.RegisterMaterial(spy); err != nil {
+        t.Fatalf("RegisterMaterial: %v", err)
+    }
+    if err := recty.SetMaterial(spy); err != nil {
+        t.Fatalf("SetMaterial: %v", err)
+    }
+    if recty.material != Material(spy) {
+        t.Fatalf("SetMaterial did not activate spy")
+    }
+}
+
+func TestSetMaterialUnregisteredReturnsError(t *testing.T) {
+    recty := &Recty{}
+    if err := recty.Init(); err != nil {
+        t.Fatalf("Init: %v", err)
+    }
+    defer recty.Delete()
+
+    if err := recty.SetMaterial(&spyMaterial{}); err == nil {
+        t.Fatal("SetMaterial: want error for an unregistered material, got nil")
+    }
+}
+
+// TestFlushRefreshesMaterialUniforms guards against the active material's
+// SetUniforms only being called from SetMaterial: a material's exported
+// fields (e.g. LitMaterial's LightDir) must take effect on every Draw, not
+// just the first one after switching to it.
+func TestFlushRefreshesMaterialUniforms(t *testing.T) {
+    recty := &Recty{}
+    if err := recty.Init(); err != nil {
+        t.Fatalf("Init: %v", err)
+    }
+    defer recty.Delete()
+
+    spy := &spyMaterial{}
+    if err := recty.RegisterMaterial(spy); err != nil {
+        t.Fatalf("RegisterMaterial: %v", err)
+    }
+    if err := recty.SetMaterial(spy); err != nil {
+        t.Fatalf("SetMaterial: %v", err)
+    }
+    afterSwitch := spy.calls
+
+    recty.Draw([15]float32{})
+    if spy.calls <= afterSwitch {
+        t.Fatalf("Draw did not refresh the active material's uniforms: calls = %d, want > %d", spy.calls, afterSwitch)
+    }
+
+    afterFirstDraw := spy.calls
+    recty.Draw([15]float32{})
+    if spy.calls <= afterFirstDraw {
+        t.Fatalf("second Draw did not refresh the active material's uniforms: calls = %d, want > %d", spy.calls, afterFirstDraw)
+    }
+}
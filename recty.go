@@ -1,9 +1,7 @@
-// Package recty provides an OpenGL renderer of coloured squares. Support for
-// textures is planned.
+// Package recty provides an OpenGL renderer of coloured, textured squares.
 package recty
 
 import (
-    "errors"
     "github.com/go-gl/gl"
     "github.com/snorredc/gome"
 )
@@ -13,16 +11,19 @@ const vertSource = `#version 150 core
 
 in vec4 rect;
 in vec4 color;
-in vec2 texcoord;
+in vec4 uvrect;
+in vec3 rotation;
 
 out vec4 vColor;
-out vec2 vTexcoord;
+out vec4 vUvrect;
+out vec3 vRotation;
 
 void main() {
     // tell OpenGL this is our vertex's location
     gl_Position = rect;
     vColor = color;
-    vTexcoord = texcoord;
+    vUvrect = uvrect;
+    vRotation = rotation;
 }
 `
 
@@ -33,25 +34,41 @@ layout(points) in;
 layout(triangle_strip, max_vertices = 4) out;
 
 in vec4 vColor[];
-in vec2 vTexcoord[];
+in vec4 vUvrect[];
+in vec3 vRotation[];
 
 uniform mat3 transform;
 
 out vec4 fColor;
 out vec2 fTexcoord;
 
+// spin rotates p by angle radians around pivot, in the rect's own
+// pre-transform coordinate space.
+vec2 spin(vec2 p, vec2 pivot, float angle) {
+    float s = sin(angle);
+    float c = cos(angle);
+    vec2 d = p - pivot;
+    return pivot + vec2(d.x * c - d.y * s, d.x * s + d.y * c);
+}
+
 void main() {
     fColor = vColor[0];
-    fTexcoord = vTexcoord[0];
     vec4 rect = gl_in[0].gl_Position;
-    
-    gl_Position = vec4(transform * vec3(rect.xy, 1), 1.0);
+    vec4 uv = vUvrect[0];
+    float angle = vRotation[0].x;
+    vec2 pivot = vRotation[0].yz;
+
+    gl_Position = vec4(transform * vec3(spin(rect.xy, pivot, angle), 1), 1.0);
+    fTexcoord = uv.xy;
     EmitVertex();
-    gl_Position = vec4(transform * vec3(rect.xw, 1), 1.0);
+    gl_Position = vec4(transform * vec3(spin(rect.xw, pivot, angle), 1), 1.0);
+    fTexcoord = uv.xw;
     EmitVertex();
-    gl_Position = vec4(transform * vec3(rect.zy, 1), 1.0);
+    gl_Position = vec4(transform * vec3(spin(rect.zy, pivot, angle), 1), 1.0);
+    fTexcoord = uv.zy;
     EmitVertex();
-    gl_Position = vec4(transform * vec3(rect.zw, 1), 1.0);
+    gl_Position = vec4(transform * vec3(spin(rect.zw, pivot, angle), 1), 1.0);
+    fTexcoord = uv.zw;
     EmitVertex();
 }
 `
@@ -70,76 +87,238 @@ out vec4 outColor;
 uniform sampler2D tex;
 
 void main() {
-    outColor = fColor + texture(tex, fTexcoord);
+    // texture is alpha-premultiplied, so it composites straight underneath
+    // the (non-premultiplied) vertex colour using the standard "over" op.
+    vec4 texColor = texture(tex, fTexcoord);
+    outColor = vec4(fColor.rgb * fColor.a + texColor.rgb * (1.0 - fColor.a), fColor.a + texColor.a * (1.0 - fColor.a));
 }
 `
 
+// blendOver mirrors fragSource's compositing formula on the CPU: fColor (a
+// non-premultiplied vertex colour) drawn over texColor (an
+// alpha-premultiplied texture sample), using the standard "over" operator.
+// It exists so the blend formula can be exercised by a table-driven test
+// without a GL context.
+func blendOver(fColor, texColor [4]float32) [4]float32 {
+    a := fColor[3]
+    return [4]float32{
+        fColor[0]*a + texColor[0]*(1-a),
+        fColor[1]*a + texColor[1]*(1-a),
+        fColor[2]*a + texColor[2]*(1-a),
+        a + texColor[3]*(1-a),
+    }
+}
+
+// ringAdvance computes where a chunk of n quads should land in a ring
+// buffer of the given capacity, currently filled up to ring. It reports
+// whether the ring must wrap (in which case the caller must orphan the
+// buffer's storage before uploading) and the ring position after the
+// chunk. It never splits a chunk; callers are expected to have already
+// capped len(chunk) to capacity.
+func ringAdvance(ring, n, capacity int) (offset int, orphan bool, next int) {
+    if ring+n > capacity {
+        ring = 0
+        orphan = true
+    }
+    return ring, orphan, ring + n
+}
+
+// defaultCapacity is the number of quads the VBO ring buffer holds when
+// Recty.Capacity is left at zero.
+const defaultCapacity = 4096
+
+// compileShader compiles src as a shader of the given stage (one of
+// gl.VERTEX_SHADER, gl.GEOMETRY_SHADER, gl.FRAGMENT_SHADER) and returns a
+// *ShaderCompileError, rather than leaving the failure to surface later as
+// an opaque link error or a -1 attribute location.
+func compileShader(stage gl.GLenum, stageName, src string) (gl.Shader, error) {
+    shader := gl.CreateShader(stage)
+    shader.Source(src)
+    shader.Compile()
+    if shader.Get(gl.COMPILE_STATUS) == gl.FALSE {
+        log := shader.GetInfoLog()
+        shader.Delete()
+        return 0, &ShaderCompileError{Stage: stageName, Log: log}
+    }
+    return shader, nil
+}
+
+// newProgram compiles vertSrc, geomSrc and fragSrc into a linked program. If
+// bind is non-nil it is called on the program after attaching shaders but
+// before linking, to fix attribute locations with BindAttribLocation. The
+// shaders themselves are deleted once linked, since the program keeps its
+// own copy of whatever it needs from them.
+func newProgram(vertSrc, geomSrc, fragSrc string, bind func(gl.Program)) (gl.Program, error) {
+    vertShader, err := compileShader(gl.VERTEX_SHADER, "vertex", vertSrc)
+    if err != nil {
+        return 0, err
+    }
+    defer vertShader.Delete()
+    geomShader, err := compileShader(gl.GEOMETRY_SHADER, "geometry", geomSrc)
+    if err != nil {
+        return 0, err
+    }
+    defer geomShader.Delete()
+    fragShader, err := compileShader(gl.FRAGMENT_SHADER, "fragment", fragSrc)
+    if err != nil {
+        return 0, err
+    }
+    defer fragShader.Delete()
+
+    program := gl.CreateProgram()
+    program.AttachShader(vertShader)
+    program.AttachShader(geomShader)
+    program.AttachShader(fragShader)
+    if bind != nil {
+        bind(program)
+    }
+    program.Link()
+    if program.Get(gl.LINK_STATUS) == gl.FALSE {
+        log := program.GetInfoLog()
+        program.Delete()
+        return 0, &ProgramLinkError{Log: log}
+    }
+    return program, nil
+}
+
+// bindRectAttribs fixes the shared geometry stage's attributes to the same
+// locations on every material's program, so rect/color/uvrect/rotation
+// attribute pointers set up once in Init stay valid no matter which
+// material's program is current when Recty draws.
+func bindRectAttribs(program gl.Program) {
+    program.BindAttribLocation(0, "rect")
+    program.BindAttribLocation(1, "color")
+    program.BindAttribLocation(2, "uvrect")
+    program.BindAttribLocation(3, "rotation")
+}
+
+// requireAttrib looks up the location of an active attribute in program and
+// returns a *LocationError instead of a silent -1 if it isn't found.
+func requireAttrib(program gl.Program, name string) (gl.AttribLocation, error) {
+    loc := program.GetAttribLocation(name)
+    if loc < 0 {
+        return loc, &LocationError{Kind: "attribute", Name: name}
+    }
+    return loc, nil
+}
+
+// requireUniform looks up the location of an active uniform in program and
+// returns a *LocationError instead of a silent -1 if it isn't found.
+func requireUniform(program gl.Program, name string) (gl.UniformLocation, error) {
+    loc := program.GetUniformLocation(name)
+    if loc < 0 {
+        return loc, &LocationError{Kind: "uniform", Name: name}
+    }
+    return loc, nil
+}
+
 // Recty is a rendering object and context.
 type Recty struct {
-    Program gl.Program
-    vao     gl.VertexArray
-    vbo     gl.Buffer
+    vao gl.VertexArray
+    vbo gl.Buffer
+
+    materials map[Material]*materialProgram
+    material  Material
+    transform [9]float32
+
+    // Capacity is the number of quads the VBO ring buffer can hold. It may
+    // be set before calling Init to size the buffer for the expected
+    // number of rects per frame; left at zero, defaultCapacity is used.
+    Capacity int
+
+    queue [][15]float32
+    ring  int
+
+    // LineWidth is the thickness of lines drawn by DrawLine/DrawLines, in
+    // the same pre-transform coordinate space as rects. It may be changed
+    // at any time between draws.
+    LineWidth float32
 
-    Transform gl.UniformLocation
+    lineProgram   gl.Program
+    lineVao       gl.VertexArray
+    lineVbo       gl.Buffer
+    lineTransform gl.UniformLocation
+    lineWidthLoc  gl.UniformLocation
+
+    linesQueue [][8]float32
+    linesRing  int
+    linesReady bool
 }
 
 // Init initialises the renderer. OpenGL should be initialised before calling
 // Init.
 func (recty *Recty) Init() error {
+    if recty.Capacity == 0 {
+        recty.Capacity = defaultCapacity
+    }
+
     recty.vao = gl.GenVertexArray()
     recty.vao.Bind()
 
-    // set the shaders and program up
-    vertShader := gl.CreateShader(gl.VERTEX_SHADER)
-    geomShader := gl.CreateShader(gl.GEOMETRY_SHADER)
-    fragShader := gl.CreateShader(gl.FRAGMENT_SHADER)
-    defer vertShader.Delete()
-    defer geomShader.Delete()
-    defer fragShader.Delete()
-    vertShader.Source(vertSource)
-    geomShader.Source(geomSource)
-    fragShader.Source(fragSource)
-    vertShader.Compile()
-    geomShader.Compile()
-    fragShader.Compile()
-
-    recty.Program = gl.CreateProgram()
-    recty.Program.AttachShader(vertShader)
-    recty.Program.AttachShader(geomShader)
-    recty.Program.AttachShader(fragShader)
-    // activate the program
-    recty.Program.Link()
-    recty.Program.Use()
-
     recty.vbo = gl.GenBuffer()
     recty.vbo.Bind(gl.ARRAY_BUFFER)
+    gl.BufferData(gl.ARRAY_BUFFER, 15*4*recty.Capacity, nil, gl.DYNAMIC_DRAW)
+
+    // Register the default material and activate it; its program fixes the
+    // shared geometry stage's attribute locations (see bindRectAttribs), so
+    // the pointers set up here stay valid for every material registered
+    // afterwards too.
+    if err := recty.RegisterMaterial(Basic); err != nil {
+        return err
+    }
+    mp := recty.materials[Basic]
+    recty.material = Basic
+    mp.program.Use()
 
-    attrRect := recty.Program.GetAttribLocation("rect")
-    attrRect.AttribPointer(4, gl.FLOAT, false, 10*4, uintptr(0))
+    attrRect, err := requireAttrib(mp.program, "rect")
+    if err != nil {
+        return err
+    }
+    attrRect.AttribPointer(4, gl.FLOAT, false, 15*4, uintptr(0))
     attrRect.EnableArray()
-    attrColor := recty.Program.GetAttribLocation("color")
-    attrColor.AttribPointer(4, gl.FLOAT, false, 10*4, uintptr(4*4))
+    attrColor, err := requireAttrib(mp.program, "color")
+    if err != nil {
+        return err
+    }
+    attrColor.AttribPointer(4, gl.FLOAT, false, 15*4, uintptr(4*4))
     attrColor.EnableArray()
-    attrTexcoord := recty.Program.GetAttribLocation("texcoord")
-    attrTexcoord.AttribPointer(2, gl.FLOAT, false, 10*4, uintptr(8*4))
-    attrTexcoord.EnableArray()
-
-    if err := gome.GetError(); err != nil {
-        return errors.New(recty.Program.GetInfoLog())
+    attrUvrect, err := requireAttrib(mp.program, "uvrect")
+    if err != nil {
+        return err
+    }
+    attrUvrect.AttribPointer(4, gl.FLOAT, false, 15*4, uintptr(8*4))
+    attrUvrect.EnableArray()
+    attrRotation, err := requireAttrib(mp.program, "rotation")
+    if err != nil {
+        return err
     }
-    // get handles for aMax and aMin.
-    recty.Transform = recty.Program.GetUniformLocation("transform")
+    attrRotation.AttribPointer(3, gl.FLOAT, false, 15*4, uintptr(12*4))
+    attrRotation.EnableArray()
+
     recty.SetTransform(
         1, 0, 0,
         0, 1, 0,
     )
-    return gome.GetError()
+    if err := gome.GetError(); err != nil {
+        return err
+    }
+
+    return recty.initLines()
 }
 
 // SetTransform sets the transformation matrix. The arguments are the first to
 // rows of the matrix, where the last is set to [0 0 1] to prevent 3D results.
+// The matrix applies to lines and to every registered material.
 func (recty *Recty) SetTransform(a, d, g, b, e, h float32) {
-    recty.Transform.UniformMatrix3f(false, &[9]float32{a, b, 0, d, e, 0, g, h, 1})
+    recty.transform = [9]float32{a, b, 0, d, e, 0, g, h, 1}
+    mp := recty.materials[recty.material]
+    mp.program.Use()
+    mp.transform.UniformMatrix3f(false, &recty.transform)
+    if recty.linesReady {
+        recty.lineProgram.Use()
+        recty.lineTransform.UniformMatrix3f(false, &recty.transform)
+        mp.program.Use()
+    }
 }
 
 // SetScale is a utility for setting the transformation matrix. It scales the
@@ -152,19 +331,103 @@ func (recty *Recty) SetScale(w, h, dx, dy float32) {
 // Draw draws rectangles directly to the screen. Each rectangle is represented
 // as
 //
-//     []float32{x1, y1, x2, y2, r, g, b, a, tx, ty}
+//     []float32{x1, y1, x2, y2, r, g, b, a, u0, v0, u1, v1, angle, px, py}
 //
 // where (x1, y1) is the lower left corner and (x2, y2) is the upper right one,
-// and (r, g, b, a) is the RGBA colour. Since textures are not implemented yet
-// tx and ty are unused.
-func (recty *Recty) Draw(rects ...[10]float32) {
+// (r, g, b, a) is the RGBA colour, (u0, v0)-(u1, v1) is the UV sub-rectangle
+// of the currently bound texture to sample across the quad, and angle is a
+// rotation in radians applied around the pivot (px, py) before the global
+// transform. A rect drawn with a zero UV region samples (0, 0) everywhere,
+// which is transparent for any texture with a cleared border, so Draw can
+// still be used for untextured solid-colour rects, and a zero angle leaves
+// the rect unrotated regardless of pivot. See DrawTextured for drawing rects
+// sourced from an Atlas and DrawRotated/RotatedRect for building rotated
+// ones. Draw is a convenience that queues rects and flushes immediately;
+// batch many Draw/Queue calls within a frame and call Flush once at the end
+// to avoid the driver stalls that come from re-specifying the buffer's
+// storage on every call.
+func (recty *Recty) Draw(rects ...[15]float32) {
+    recty.Queue(rects...)
+    recty.Flush()
+}
+
+// DrawTextured binds atlas's texture and draws rects against it. The u0, v0,
+// u1, v1 fields of each rect should be UVs returned by atlas.AddImage (see
+// UV.Inset to avoid atlas bleeding).
+func (recty *Recty) DrawTextured(atlas *Atlas, rects ...[15]float32) {
+    atlas.Bind()
+    recty.Draw(rects...)
+}
+
+// DrawRotated draws rects that carry their own per-rectangle rotation. It is
+// equivalent to Draw, since rects always carry an angle and pivot; it exists
+// so call sites that rotate can say so. See RotatedRect for a convenient way
+// to build such a rect.
+func (recty *Recty) DrawRotated(rects ...[15]float32) {
+    recty.Draw(rects...)
+}
+
+// RotatedRect builds a rect of size w x h centred at (cx, cy), coloured rgba
+// and rotated by angle radians around its own centre.
+func RotatedRect(cx, cy, w, h, angle float32, rgba [4]float32) [15]float32 {
+    x1, y1 := cx-w/2, cy-h/2
+    x2, y2 := cx+w/2, cy+h/2
+    return [15]float32{
+        x1, y1, x2, y2,
+        rgba[0], rgba[1], rgba[2], rgba[3],
+        0, 0, 0, 0,
+        angle, cx, cy,
+    }
+}
+
+// Queue appends rects to the pending batch without drawing them. Call Flush
+// to upload and draw everything queued so far.
+func (recty *Recty) Queue(rects ...[15]float32) {
+    recty.queue = append(recty.queue, rects...)
+}
+
+// Flush uploads the queued rects into the VBO ring buffer and draws them,
+// then clears the queue. Uploads use glBufferSubData so the buffer's
+// storage is only re-specified (orphaned) when the ring wraps, letting the
+// driver hand back a fresh allocation instead of stalling on a draw that
+// may still be in flight. The active material's uniforms (including any
+// that change frame-to-frame, such as LitMaterial's light) are refreshed
+// on every Flush, not just on SetMaterial, so a caller can mutate a
+// material's fields and keep drawing without forcing a program switch.
+func (recty *Recty) Flush() {
+    if len(recty.queue) == 0 {
+        return
+    }
+    mp := recty.materials[recty.material]
+    recty.vao.Bind()
+    mp.program.Use()
+    recty.material.SetUniforms(mp.program)
     recty.vbo.Bind(gl.ARRAY_BUFFER)
-    gl.BufferData(gl.ARRAY_BUFFER, 10*4*len(rects), rects, gl.STATIC_DRAW)
-    gl.DrawArrays(gl.POINTS, 0, len(rects))
+    for len(recty.queue) > 0 {
+        chunk := recty.queue
+        if len(chunk) > recty.Capacity {
+            chunk = chunk[:recty.Capacity]
+        }
+        offset, orphan, next := ringAdvance(recty.ring, len(chunk), recty.Capacity)
+        if orphan {
+            gl.BufferData(gl.ARRAY_BUFFER, 15*4*recty.Capacity, nil, gl.DYNAMIC_DRAW)
+        }
+        gl.BufferSubData(gl.ARRAY_BUFFER, 15*4*offset, chunk)
+        gl.DrawArrays(gl.POINTS, offset, len(chunk))
+        recty.ring = next
+        recty.queue = recty.queue[len(chunk):]
+    }
+    recty.queue = recty.queue[:0]
 }
 
 // Delete deletes the Recty freeing any related resources.
 func (recty *Recty) Delete() {
-    recty.Program.Delete()
+    for _, mp := range recty.materials {
+        mp.program.Delete()
+    }
     recty.vao.Delete()
+    if recty.linesReady {
+        recty.lineProgram.Delete()
+        recty.lineVao.Delete()
+    }
 }
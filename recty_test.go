@@ -0,0 +1,62 @@
+package recty
+
+import "testing"
+
+func TestBlendOver(t *testing.T) {
+    tests := []struct {
+        name            string
+        fColor, texColor [4]float32
+        want            [4]float32
+    }{
+        {
+            name:     "opaque vertex colour fully occludes texture",
+            fColor:   [4]float32{1, 0, 0, 1},
+            texColor: [4]float32{0, 0, 1, 1},
+            want:     [4]float32{1, 0, 0, 1},
+        },
+        {
+            name:     "transparent vertex colour passes texture through",
+            fColor:   [4]float32{1, 0, 0, 0},
+            texColor: [4]float32{0, 0, 1, 1},
+            want:     [4]float32{0, 0, 1, 1},
+        },
+        {
+            name:     "half-alpha vertex colour mixes with texture",
+            fColor:   [4]float32{1, 0, 0, 0.5},
+            texColor: [4]float32{0, 1, 0, 1},
+            want:     [4]float32{0.5, 0.5, 0, 1},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := blendOver(tt.fColor, tt.texColor)
+            if got != tt.want {
+                t.Errorf("blendOver(%v, %v) = %v, want %v", tt.fColor, tt.texColor, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestRingAdvance(t *testing.T) {
+    tests := []struct {
+        name                 string
+        ring, n, capacity    int
+        wantOffset           int
+        wantOrphan           bool
+        wantNext             int
+    }{
+        {"fits without wrapping", 10, 5, 100, 10, false, 15},
+        {"exactly fills the ring", 90, 10, 100, 90, false, 100},
+        {"wraps when it would overflow", 95, 10, 100, 0, true, 10},
+        {"fills an empty ring with a chunk as big as capacity without wrapping", 0, 100, 100, 0, false, 100},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            offset, orphan, next := ringAdvance(tt.ring, tt.n, tt.capacity)
+            if offset != tt.wantOffset || orphan != tt.wantOrphan || next != tt.wantNext {
+                t.Errorf("ringAdvance(%d, %d, %d) = (%d, %v, %d), want (%d, %v, %d)",
+                    tt.ring, tt.n, tt.capacity, offset, orphan, next, tt.wantOffset, tt.wantOrphan, tt.wantNext)
+            }
+        })
+    }
+}
@@ -0,0 +1,147 @@
+package recty
+
+import (
+    "errors"
+    "image"
+    "image/draw"
+
+    "github.com/go-gl/gl"
+)
+
+// Texture wraps a single GL texture object.
+type Texture struct {
+    id   gl.Texture
+    w, h int
+}
+
+// NewTexture allocates a GL texture and uploads img, converting it to RGBA
+// first if necessary.
+func NewTexture(img image.Image) *Texture {
+    rgba := toRGBA(img)
+    b := rgba.Bounds()
+
+    tex := &Texture{id: gl.GenTexture(), w: b.Dx(), h: b.Dy()}
+    tex.Bind()
+    gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, tex.w, tex.h, 0, gl.RGBA, gl.UNSIGNED_BYTE, rgba.Pix)
+    gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+    gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+    gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+    gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+    return tex
+}
+
+// Bind binds the texture to texture unit 0.
+func (tex *Texture) Bind() {
+    gl.ActiveTexture(gl.TEXTURE0)
+    tex.id.Bind(gl.TEXTURE_2D)
+}
+
+// Delete deletes the texture freeing any related resources.
+func (tex *Texture) Delete() {
+    tex.id.Delete()
+}
+
+// toRGBA returns img as an *image.RGBA, converting it if necessary.
+func toRGBA(img image.Image) *image.RGBA {
+    if rgba, ok := img.(*image.RGBA); ok {
+        return rgba
+    }
+    rgba := image.NewRGBA(img.Bounds())
+    draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+    return rgba
+}
+
+// UV is a sub-rectangle of an Atlas's texture in normalised [0, 1]
+// coordinates, ready to be copied into the u0, v0, u1, v1 fields of a rect
+// passed to Draw or DrawTextured.
+type UV struct {
+    U0, V0, U1, V1 float32
+}
+
+// Inset shrinks uv by half a texel on each side. This keeps the sampler
+// away from neighbouring sub-rects when the atlas texture is minified or
+// magnified, which otherwise bleeds their edge pixels into uv.
+func (uv UV) Inset(atlasW, atlasH int) UV {
+    hx := 0.5 / float32(atlasW)
+    hy := 0.5 / float32(atlasH)
+    return UV{uv.U0 + hx, uv.V0 + hy, uv.U1 - hx, uv.V1 - hy}
+}
+
+// Atlas packs a number of images into a single GPU texture using a simple
+// shelf packing algorithm, and hands out the UV sub-rectangle each image was
+// placed at.
+type Atlas struct {
+    w, h    int
+    rgba    *image.RGBA
+    tex     *Texture
+    dirty   bool
+    shelfY  int
+    shelfH  int
+    cursorX int
+}
+
+// NewAtlas creates an empty atlas backed by a w x h texture. Packing starts
+// one texel in on both axes rather than at the origin, leaving the (0, 0)
+// texel permanently cleared so a zero UV region (as used for untextured
+// Draw calls while an atlas is bound) keeps sampling transparent black.
+func NewAtlas(w, h int) *Atlas {
+    return &Atlas{w: w, h: h, rgba: image.NewRGBA(image.Rect(0, 0, w, h)), cursorX: 1, shelfY: 1}
+}
+
+// AddImage packs img into the atlas and returns the UV region it was placed
+// at. It returns an error if the atlas has run out of room.
+func (a *Atlas) AddImage(img image.Image) (UV, error) {
+    src := toRGBA(img)
+    b := src.Bounds()
+    iw, ih := b.Dx(), b.Dy()
+
+    if a.cursorX+iw > a.w {
+        a.cursorX = 0
+        a.shelfY += a.shelfH
+        a.shelfH = 0
+    }
+    if a.shelfY+ih > a.h {
+        return UV{}, errors.New("recty: atlas out of space")
+    }
+
+    dst := image.Rect(a.cursorX, a.shelfY, a.cursorX+iw, a.shelfY+ih)
+    draw.Draw(a.rgba, dst, src, b.Min, draw.Src)
+
+    uv := UV{
+        U0: float32(a.cursorX) / float32(a.w),
+        V0: float32(a.shelfY) / float32(a.h),
+        U1: float32(a.cursorX+iw) / float32(a.w),
+        V1: float32(a.shelfY+ih) / float32(a.h),
+    }
+
+    a.cursorX += iw
+    if ih > a.shelfH {
+        a.shelfH = ih
+    }
+    a.dirty = true
+    return uv, nil
+}
+
+// Bind uploads any pixels added since the last Bind and binds the atlas's
+// texture to texture unit 0.
+func (a *Atlas) Bind() {
+    if a.tex == nil {
+        a.tex = NewTexture(a.rgba)
+        a.dirty = false
+        return
+    }
+    if a.dirty {
+        a.tex.Bind()
+        gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, a.w, a.h, gl.RGBA, gl.UNSIGNED_BYTE, a.rgba.Pix)
+        a.dirty = false
+        return
+    }
+    a.tex.Bind()
+}
+
+// Delete deletes the atlas's texture, if it has been created.
+func (a *Atlas) Delete() {
+    if a.tex != nil {
+        a.tex.Delete()
+    }
+}
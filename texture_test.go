@@ -0,0 +1,56 @@
+package recty
+
+import (
+    "image"
+    "testing"
+)
+
+func TestAtlasAddImageReservesOrigin(t *testing.T) {
+    atlas := NewAtlas(8, 8)
+
+    uv, err := atlas.AddImage(image.NewRGBA(image.Rect(0, 0, 2, 2)))
+    if err != nil {
+        t.Fatalf("AddImage: %v", err)
+    }
+    if uv.U0 == 0 || uv.V0 == 0 {
+        t.Fatalf("first image placed at the atlas origin, got UV %+v", uv)
+    }
+}
+
+func TestAtlasAddImagePacking(t *testing.T) {
+    atlas := NewAtlas(8, 8)
+
+    tests := []struct {
+        w, h int
+        want UV
+    }{
+        {2, 2, UV{1.0 / 8, 1.0 / 8, 3.0 / 8, 3.0 / 8}},
+        {2, 3, UV{3.0 / 8, 1.0 / 8, 5.0 / 8, 4.0 / 8}},
+        {6, 2, UV{0, 4.0 / 8, 6.0 / 8, 6.0 / 8}},
+    }
+    for _, tt := range tests {
+        uv, err := atlas.AddImage(image.NewRGBA(image.Rect(0, 0, tt.w, tt.h)))
+        if err != nil {
+            t.Fatalf("AddImage(%dx%d): %v", tt.w, tt.h, err)
+        }
+        if uv != tt.want {
+            t.Errorf("AddImage(%dx%d) = %+v, want %+v", tt.w, tt.h, uv, tt.want)
+        }
+    }
+}
+
+func TestAtlasAddImageOutOfSpace(t *testing.T) {
+    atlas := NewAtlas(4, 4)
+    if _, err := atlas.AddImage(image.NewRGBA(image.Rect(0, 0, 4, 4))); err == nil {
+        t.Fatal("AddImage: want error packing an image that does not fit, got nil")
+    }
+}
+
+func TestUVInset(t *testing.T) {
+    uv := UV{U0: 0, V0: 0, U1: 1, V1: 1}
+    got := uv.Inset(4, 8)
+    want := UV{U0: 0.5 / 4, V0: 0.5 / 8, U1: 1 - 0.5/4, V1: 1 - 0.5/8}
+    if got != want {
+        t.Errorf("Inset(4, 8) = %+v, want %+v", got, want)
+    }
+}